@@ -33,58 +33,105 @@ type Expression interface {
 type Module struct {
 	Name        string
 	Definitions []*Definition
+	Position    *Position
 }
 
 // A Nil is a nil literal value.
-type Nil struct{}
+type Nil struct {
+	Position *Position
+}
 
 // A Boolean is a boolean literal value.
 type Boolean struct {
-	Value bool
+	Value    bool
+	Position *Position
 }
 
 // An Int32 is a 32-bit integer literal value.
 type Int32 struct {
-	Value int32
+	Value    int32
+	Position *Position
 }
 
 // A Float64 is a 64-bit floating point literal value.
 type Float64 struct {
-	Value float64
+	Value    float64
+	Position *Position
 }
 
 // A String is a string literal value.
 type String struct {
-	Value string
+	Value    string
+	Position *Position
 }
 
 // An ID is an identifier.
 type ID struct {
-	Value string
+	Value    string
+	Position *Position
 }
 
 // A Definition is a variable definition.
 type Definition struct {
 	ID         *ID
+	Annotation *TypeAnnotation
 	Expression Expression
+	Position   *Position
 }
 
 // A Lambda is a lambda procedure definition.
 type Lambda struct {
 	Parameters []*ID
-	Expression Expression
+	// ParameterAnnotations holds a TypeAnnotation for each entry in
+	// Parameters, or nil at an index whose parameter is unannotated. It is
+	// nil when no parameter is annotated.
+	ParameterAnnotations []*TypeAnnotation
+	ReturnAnnotation     *TypeAnnotation
+	Expression           Expression
+	Position             *Position
+}
+
+// Primitive TypeAnnotation values.
+const (
+	TypeAnnotationNil     = "nil"
+	TypeAnnotationBoolean = "bool"
+	TypeAnnotationInt32   = "int32"
+	TypeAnnotationFloat64 = "float64"
+	TypeAnnotationString  = "string"
+)
+
+// TypeAny is the TypeAnnotation Value used for a type that could not be
+// determined, such as that of an unannotated parameter or return value
+// (literals have their own primitive types). Check treats it as compatible
+// with every other type, so typed and untyped definitions can mix freely.
+const TypeAny = "any"
+
+// A TypeAnnotation declares the type of a Definition or Lambda parameter: a
+// primitive, named by Value, or a function type, described by Params and
+// Ret.
+type TypeAnnotation struct {
+	Value  string
+	Params []*TypeAnnotation
+	Ret    *TypeAnnotation
+}
+
+// IsFunction reports whether t describes a function type.
+func (t *TypeAnnotation) IsFunction() bool {
+	return t.Ret != nil
 }
 
 // A Call is a procedure call.
 type Call struct {
 	ID        *ID
 	Arguments []Expression
+	Position  *Position
 }
 
 // An AnonymousCall is an anonymous procedure call.
 type AnonymousCall struct {
 	Lambda    *Lambda
 	Arguments []Expression
+	Position  *Position
 }
 
 // Type returns the module type.
@@ -0,0 +1,622 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrInvalidBinary signals an invalid or truncated binary AST payload.
+var ErrInvalidBinary = errors.New("invalid binary AST payload")
+
+// Marshal encodes e in a compact, length-prefixed binary format: a 1-byte
+// type tag matching the Type constants, followed by type-specific payloads.
+// String and ID values are deduplicated into an intern table written once at
+// the head of the stream and referenced by varint index in the body.
+func Marshal(e Expression) ([]byte, error) {
+	table, index := internTable(e)
+
+	var w binaryWriter
+	w.writeUvarint(uint64(len(table)))
+	for _, s := range table {
+		w.writeString(s)
+	}
+
+	if err := encodeExpression(&w, e, index); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// Unmarshal decodes an Expression previously produced by Marshal.
+func Unmarshal(data []byte) (Expression, error) {
+	r := newBinaryReader(data)
+
+	count, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make([]string, count)
+	for i := range table {
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		table[i] = s
+	}
+
+	return decodeExpression(r, table)
+}
+
+// internTable collects the distinct String and ID values e actually encodes,
+// in order of first occurrence, for deduplication in the binary format's
+// header. It walks the same nodes encodeExpression does, which includes
+// Definition.ID and Call.ID — unlike Walk, which treats those as names
+// rather than children.
+func internTable(e Expression) ([]string, map[string]int) {
+	var table []string
+	index := make(map[string]int)
+
+	intern := func(value string) {
+		if _, ok := index[value]; !ok {
+			index[value] = len(table)
+			table = append(table, value)
+		}
+	}
+
+	var visit func(e Expression)
+	visit = func(e Expression) {
+		switch e := e.(type) {
+		case *Module:
+			for _, d := range e.Definitions {
+				visit(d)
+			}
+		case *String:
+			intern(e.Value)
+		case *ID:
+			intern(e.Value)
+		case *Definition:
+			visit(e.ID)
+			visit(e.Expression)
+		case *Lambda:
+			for _, p := range e.Parameters {
+				visit(p)
+			}
+			visit(e.Expression)
+		case *Call:
+			visit(e.ID)
+			for _, a := range e.Arguments {
+				visit(a)
+			}
+		case *AnonymousCall:
+			visit(e.Lambda)
+			for _, a := range e.Arguments {
+				visit(a)
+			}
+		}
+	}
+	visit(e)
+
+	return table, index
+}
+
+func encodeExpression(w *binaryWriter, e Expression, index map[string]int) error {
+	w.writeByte(byte(e.Type()))
+	w.writePosition(positionOf(e))
+
+	switch e := e.(type) {
+	case *Module:
+		w.writeString(e.Name)
+		w.writeUvarint(uint64(len(e.Definitions)))
+		for _, d := range e.Definitions {
+			if err := encodeExpression(w, d, index); err != nil {
+				return err
+			}
+		}
+	case *Nil:
+	case *Boolean:
+		if e.Value {
+			w.writeByte(1)
+		} else {
+			w.writeByte(0)
+		}
+	case *Int32:
+		w.writeVarint(int64(e.Value))
+	case *Float64:
+		w.writeFloat64(e.Value)
+	case *String:
+		w.writeUvarint(uint64(index[e.Value]))
+	case *ID:
+		w.writeUvarint(uint64(index[e.Value]))
+	case *Definition:
+		if err := encodeExpression(w, e.ID, index); err != nil {
+			return err
+		}
+		w.writeTypeAnnotation(e.Annotation)
+		if err := encodeExpression(w, e.Expression, index); err != nil {
+			return err
+		}
+	case *Lambda:
+		w.writeUvarint(uint64(len(e.Parameters)))
+		for _, p := range e.Parameters {
+			if err := encodeExpression(w, p, index); err != nil {
+				return err
+			}
+		}
+		if e.ParameterAnnotations == nil {
+			w.writeUvarint(0)
+		} else {
+			w.writeUvarint(uint64(len(e.ParameterAnnotations)))
+			for _, a := range e.ParameterAnnotations {
+				w.writeTypeAnnotation(a)
+			}
+		}
+		w.writeTypeAnnotation(e.ReturnAnnotation)
+		if err := encodeExpression(w, e.Expression, index); err != nil {
+			return err
+		}
+	case *Call:
+		if err := encodeExpression(w, e.ID, index); err != nil {
+			return err
+		}
+		w.writeUvarint(uint64(len(e.Arguments)))
+		for _, a := range e.Arguments {
+			if err := encodeExpression(w, a, index); err != nil {
+				return err
+			}
+		}
+	case *AnonymousCall:
+		if err := encodeExpression(w, e.Lambda, index); err != nil {
+			return err
+		}
+		w.writeUvarint(uint64(len(e.Arguments)))
+		for _, a := range e.Arguments {
+			if err := encodeExpression(w, a, index); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("ast: cannot encode %T", e)
+	}
+
+	return nil
+}
+
+// positionOf returns e's Position field.
+func positionOf(e Expression) *Position {
+	switch e := e.(type) {
+	case *Module:
+		return e.Position
+	case *Nil:
+		return e.Position
+	case *Boolean:
+		return e.Position
+	case *Int32:
+		return e.Position
+	case *Float64:
+		return e.Position
+	case *String:
+		return e.Position
+	case *ID:
+		return e.Position
+	case *Definition:
+		return e.Position
+	case *Lambda:
+		return e.Position
+	case *Call:
+		return e.Position
+	case *AnonymousCall:
+		return e.Position
+	default:
+		return nil
+	}
+}
+
+func decodeExpression(r *binaryReader, table []string) (Expression, error) {
+	tag, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	position, err := r.readPosition()
+	if err != nil {
+		return nil, err
+	}
+
+	switch Type(tag) {
+	case TypeModule:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		count, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		definitions := make([]*Definition, count)
+		for i := range definitions {
+			d, err := decodeExpression(r, table)
+			if err != nil {
+				return nil, err
+			}
+			definition, ok := d.(*Definition)
+			if !ok {
+				return nil, ErrInvalidBinary
+			}
+			definitions[i] = definition
+		}
+		return &Module{Name: name, Definitions: definitions, Position: position}, nil
+
+	case TypeNil:
+		return &Nil{Position: position}, nil
+
+	case TypeBoolean:
+		b, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return &Boolean{Value: b == 1, Position: position}, nil
+
+	case TypeInt32:
+		v, err := r.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		return &Int32{Value: int32(v), Position: position}, nil
+
+	case TypeFloat64:
+		v, err := r.readFloat64()
+		if err != nil {
+			return nil, err
+		}
+		return &Float64{Value: v, Position: position}, nil
+
+	case TypeString:
+		value, err := r.readInterned(table)
+		if err != nil {
+			return nil, err
+		}
+		return &String{Value: value, Position: position}, nil
+
+	case TypeID:
+		value, err := r.readInterned(table)
+		if err != nil {
+			return nil, err
+		}
+		return &ID{Value: value, Position: position}, nil
+
+	case TypeDefinition:
+		id, err := decodeExpression(r, table)
+		if err != nil {
+			return nil, err
+		}
+		idNode, ok := id.(*ID)
+		if !ok {
+			return nil, ErrInvalidBinary
+		}
+		annotation, err := r.readTypeAnnotation()
+		if err != nil {
+			return nil, err
+		}
+		expression, err := decodeExpression(r, table)
+		if err != nil {
+			return nil, err
+		}
+		return &Definition{ID: idNode, Annotation: annotation, Expression: expression, Position: position}, nil
+
+	case TypeLambda:
+		count, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		parameters := make([]*ID, count)
+		for i := range parameters {
+			p, err := decodeExpression(r, table)
+			if err != nil {
+				return nil, err
+			}
+			param, ok := p.(*ID)
+			if !ok {
+				return nil, ErrInvalidBinary
+			}
+			parameters[i] = param
+		}
+		annotationCount, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		var parameterAnnotations []*TypeAnnotation
+		if annotationCount > 0 {
+			parameterAnnotations = make([]*TypeAnnotation, annotationCount)
+			for i := range parameterAnnotations {
+				a, err := r.readTypeAnnotation()
+				if err != nil {
+					return nil, err
+				}
+				parameterAnnotations[i] = a
+			}
+		}
+		returnAnnotation, err := r.readTypeAnnotation()
+		if err != nil {
+			return nil, err
+		}
+		expression, err := decodeExpression(r, table)
+		if err != nil {
+			return nil, err
+		}
+		return &Lambda{
+			Parameters:           parameters,
+			ParameterAnnotations: parameterAnnotations,
+			ReturnAnnotation:     returnAnnotation,
+			Expression:           expression,
+			Position:             position,
+		}, nil
+
+	case TypeCall:
+		id, err := decodeExpression(r, table)
+		if err != nil {
+			return nil, err
+		}
+		idNode, ok := id.(*ID)
+		if !ok {
+			return nil, ErrInvalidBinary
+		}
+		count, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		arguments := make([]Expression, count)
+		for i := range arguments {
+			a, err := decodeExpression(r, table)
+			if err != nil {
+				return nil, err
+			}
+			arguments[i] = a
+		}
+		return &Call{ID: idNode, Arguments: arguments, Position: position}, nil
+
+	case TypeAnonymousCall:
+		l, err := decodeExpression(r, table)
+		if err != nil {
+			return nil, err
+		}
+		lambda, ok := l.(*Lambda)
+		if !ok {
+			return nil, ErrInvalidBinary
+		}
+		count, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		arguments := make([]Expression, count)
+		for i := range arguments {
+			a, err := decodeExpression(r, table)
+			if err != nil {
+				return nil, err
+			}
+			arguments[i] = a
+		}
+		return &AnonymousCall{Lambda: lambda, Arguments: arguments, Position: position}, nil
+
+	default:
+		return nil, ErrInvalidBinary
+	}
+}
+
+// binaryWriter accumulates the varint- and length-prefixed encoding used by
+// Marshal. Writes to the underlying bytes.Buffer never fail.
+type binaryWriter struct {
+	buf     bytes.Buffer
+	varintB [binary.MaxVarintLen64]byte
+}
+
+func (w *binaryWriter) writeByte(b byte) {
+	w.buf.WriteByte(b)
+}
+
+func (w *binaryWriter) writeUvarint(x uint64) {
+	n := binary.PutUvarint(w.varintB[:], x)
+	w.buf.Write(w.varintB[:n])
+}
+
+func (w *binaryWriter) writeVarint(x int64) {
+	n := binary.PutVarint(w.varintB[:], x)
+	w.buf.Write(w.varintB[:n])
+}
+
+func (w *binaryWriter) writeFloat64(f float64) {
+	binary.BigEndian.PutUint64(w.varintB[:8], math.Float64bits(f))
+	w.buf.Write(w.varintB[:8])
+}
+
+func (w *binaryWriter) writeString(s string) {
+	w.writeUvarint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// writeTypeAnnotation writes t, or a single absent marker byte if t is nil.
+// TypeAnnotation values are not interned: they are small and, unlike String
+// and ID nodes, not walked by internTable.
+func (w *binaryWriter) writeTypeAnnotation(t *TypeAnnotation) {
+	if t == nil {
+		w.writeByte(0)
+		return
+	}
+	w.writeByte(1)
+	w.writeString(t.Value)
+	w.writeUvarint(uint64(len(t.Params)))
+	for _, p := range t.Params {
+		w.writeTypeAnnotation(p)
+	}
+	w.writeTypeAnnotation(t.Ret)
+}
+
+// writePosition writes p, or a single absent marker byte if p is nil.
+func (w *binaryWriter) writePosition(p *Position) {
+	if p == nil {
+		w.writeByte(0)
+		return
+	}
+	w.writeByte(1)
+	w.writeString(p.File)
+	w.writeVarint(int64(p.Line))
+	w.writeVarint(int64(p.Column))
+	w.writeVarint(int64(p.Offset))
+	w.writeVarint(int64(p.EndLine))
+	w.writeVarint(int64(p.EndColumn))
+	w.writeVarint(int64(p.EndOffset))
+}
+
+// binaryReader consumes the format written by binaryWriter.
+type binaryReader struct {
+	r *bytes.Reader
+}
+
+func newBinaryReader(data []byte) *binaryReader {
+	return &binaryReader{r: bytes.NewReader(data)}
+}
+
+func (r *binaryReader) readByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, ErrInvalidBinary
+	}
+	return b, nil
+}
+
+func (r *binaryReader) readUvarint() (uint64, error) {
+	x, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, ErrInvalidBinary
+	}
+	return x, nil
+}
+
+func (r *binaryReader) readVarint() (int64, error) {
+	x, err := binary.ReadVarint(r.r)
+	if err != nil {
+		return 0, ErrInvalidBinary
+	}
+	return x, nil
+}
+
+// readTypeAnnotation reads a value written by writeTypeAnnotation, returning
+// nil if none was present.
+func (r *binaryReader) readTypeAnnotation() (*TypeAnnotation, error) {
+	present, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	if present == 0 {
+		return nil, nil
+	}
+
+	value, err := r.readString()
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	var params []*TypeAnnotation
+	if count > 0 {
+		params = make([]*TypeAnnotation, count)
+		for i := range params {
+			p, err := r.readTypeAnnotation()
+			if err != nil {
+				return nil, err
+			}
+			params[i] = p
+		}
+	}
+	ret, err := r.readTypeAnnotation()
+	if err != nil {
+		return nil, err
+	}
+	return &TypeAnnotation{Value: value, Params: params, Ret: ret}, nil
+}
+
+// readPosition reads a value written by writePosition, returning nil if none
+// was present.
+func (r *binaryReader) readPosition() (*Position, error) {
+	present, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	if present == 0 {
+		return nil, nil
+	}
+
+	file, err := r.readString()
+	if err != nil {
+		return nil, err
+	}
+	line, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	column, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	offset, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	endLine, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	endColumn, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	endOffset, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	return &Position{
+		File:      file,
+		Line:      int(line),
+		Column:    int(column),
+		Offset:    int(offset),
+		EndLine:   int(endLine),
+		EndColumn: int(endColumn),
+		EndOffset: int(endOffset),
+	}, nil
+}
+
+func (r *binaryReader) readFloat64() (float64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r.r, b[:]); err != nil {
+		return 0, ErrInvalidBinary
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b[:])), nil
+}
+
+func (r *binaryReader) readString() (string, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return "", ErrInvalidBinary
+	}
+	return string(b), nil
+}
+
+func (r *binaryReader) readInterned(table []string) (string, error) {
+	i, err := r.readUvarint()
+	if err != nil {
+		return "", err
+	}
+	if i >= uint64(len(table)) {
+		return "", ErrInvalidBinary
+	}
+	return table[i], nil
+}
@@ -0,0 +1,288 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalUnmarshalRoundTrip(t *testing.T) {
+	expressions := []Expression{
+		&Module{
+			Name: "test",
+			Definitions: []*Definition{
+				{
+					ID: &ID{Value: "main"},
+					Expression: &Lambda{
+						Parameters: []*ID{{Value: "x"}},
+						Expression: &Call{
+							ID:        &ID{Value: "double"},
+							Arguments: []Expression{&ID{Value: "x"}},
+						},
+					},
+				},
+			},
+		},
+		&Nil{},
+		&Boolean{Value: true},
+		&Int32{Value: -42},
+		&Float64{Value: 0.0000582},
+		&String{Value: "Hello, world!"},
+		&ID{Value: "id"},
+		&AnonymousCall{
+			Lambda: &Lambda{
+				Parameters: []*ID{{Value: "a"}},
+				Expression: &ID{Value: "a"},
+			},
+			Arguments: []Expression{&String{Value: "a's value"}},
+		},
+		&Definition{
+			ID:         &ID{Value: "add"},
+			Annotation: &TypeAnnotation{Params: []*TypeAnnotation{{Value: TypeAnnotationInt32}, {Value: TypeAnnotationInt32}}, Ret: &TypeAnnotation{Value: TypeAnnotationInt32}},
+			Expression: &Lambda{
+				Parameters:           []*ID{{Value: "x"}, {Value: "y"}},
+				ParameterAnnotations: []*TypeAnnotation{{Value: TypeAnnotationInt32}, nil},
+				ReturnAnnotation:     &TypeAnnotation{Value: TypeAnnotationInt32},
+				Expression:           &ID{Value: "x"},
+			},
+		},
+	}
+
+	for _, e := range expressions {
+		t.Run(fmt.Sprintf("%T", e), func(t *testing.T) {
+			b, err := Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := Unmarshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(e, decoded) {
+				t.Errorf("expected %#v, got %#v", e, decoded)
+			}
+		})
+	}
+}
+
+func TestMarshalUnmarshalRoundTripPosition(t *testing.T) {
+	pos := func(line int) *Position {
+		return &Position{File: "main.rogue", Line: line, Column: 1, Offset: line * 10, EndLine: line, EndColumn: 5, EndOffset: line*10 + 4}
+	}
+
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID:         &ID{Value: "main", Position: pos(2)},
+				Annotation: &TypeAnnotation{Value: TypeAnnotationInt32},
+				Expression: &Lambda{
+					Parameters: []*ID{{Value: "x", Position: pos(3)}},
+					Expression: &Call{
+						ID:        &ID{Value: "double", Position: pos(4)},
+						Arguments: []Expression{&ID{Value: "x", Position: pos(4)}},
+						Position:  pos(4),
+					},
+					Position: pos(3),
+				},
+				Position: pos(2),
+			},
+			{
+				ID: &ID{Value: "values"},
+				Expression: &AnonymousCall{
+					Lambda: &Lambda{
+						Parameters: []*ID{{Value: "a"}},
+						Expression: &ID{Value: "a"},
+					},
+					Arguments: []Expression{
+						&Nil{Position: pos(5)},
+						&Boolean{Value: true, Position: pos(6)},
+						&Int32{Value: -42, Position: pos(7)},
+						&Float64{Value: 0.0000582, Position: pos(8)},
+						&String{Value: "Hello, world!", Position: pos(9)},
+					},
+					Position: pos(5),
+				},
+			},
+		},
+		Position: pos(1),
+	}
+
+	b, err := Marshal(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(module, decoded) {
+		t.Errorf("expected %#v, got %#v", module, decoded)
+	}
+}
+
+func TestMarshalInternsRepeatedValues(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{ID: &ID{Value: "repeated"}, Expression: &ID{Value: "repeated"}},
+			{ID: &ID{Value: "repeated"}, Expression: &ID{Value: "repeated"}},
+		},
+	}
+
+	table, _ := internTable(module)
+	if len(table) != 1 {
+		t.Errorf("expected 1 interned value, got %d: %v", len(table), table)
+	}
+
+	b, err := Marshal(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(module, decoded) {
+		t.Errorf("expected %#v, got %#v", module, decoded)
+	}
+}
+
+func TestUnmarshalInvalidBinary(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		{0x00},
+		{0x00, 0xFF},
+	} {
+		if _, err := Unmarshal(data); err != ErrInvalidBinary {
+			t.Errorf("expected %v, got %v", ErrInvalidBinary, err)
+		}
+	}
+}
+
+func deeplyNestedLambda(depth int) Expression {
+	var e Expression = &ID{Value: "x"}
+	for i := 0; i < depth; i++ {
+		e = &Lambda{
+			Parameters: []*ID{{Value: fmt.Sprintf("p%d", i)}},
+			Expression: e,
+		}
+	}
+	return e
+}
+
+func largeModule(n int) *Module {
+	definitions := make([]*Definition, n)
+	for i := 0; i < n; i++ {
+		definitions[i] = &Definition{
+			ID: &ID{Value: fmt.Sprintf("def%d", i)},
+			Expression: &Call{
+				ID:        &ID{Value: "add"},
+				Arguments: []Expression{&Int32{Value: int32(i)}, &String{Value: "value"}},
+			},
+		}
+	}
+	return &Module{Name: "large", Definitions: definitions}
+}
+
+func BenchmarkMarshalBinaryDeeplyNestedLambda(b *testing.B) {
+	e := deeplyNestedLambda(500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalJSONDeeplyNestedLambda(b *testing.B) {
+	e := deeplyNestedLambda(500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(e.JSON()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalBinaryDeeplyNestedLambda(b *testing.B) {
+	e := deeplyNestedLambda(500)
+	data, err := Marshal(e)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalJSONDeeplyNestedLambda(b *testing.B) {
+	e := deeplyNestedLambda(500)
+	data, err := json.Marshal(e.JSON())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewExpressionFromJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalBinaryLargeModule(b *testing.B) {
+	m := largeModule(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalJSONLargeModule(b *testing.B) {
+	m := largeModule(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(m.JSON()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalBinaryLargeModule(b *testing.B) {
+	m := largeModule(1000)
+	data, err := Marshal(m)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalJSONLargeModule(b *testing.B) {
+	m := largeModule(1000)
+	data, err := json.Marshal(m.JSON())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewExpressionFromJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
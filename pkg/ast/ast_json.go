@@ -1,204 +1,383 @@
 package ast
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
-
-	jsoniter "github.com/json-iterator/go"
+	"strings"
 )
 
 // ErrInvalidJSON signals invalid JSON.
 var ErrInvalidJSON = errors.New("invalid JSON")
 
-// NewExpressionFromJSON constructs an Expression from JSON.
-func NewExpressionFromJSON(json jsoniter.Any) (Expression, error) {
+// NewExpressionFromJSON constructs an Expression from JSON, decoded with the
+// current Backend (see SetJSONBackend).
+func NewExpressionFromJSON(data []byte) (Expression, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseExpression(json)
+}
+
+// NewModuleFromJSON constructs a Module from JSON, decoded with the current
+// Backend (see SetJSONBackend).
+func NewModuleFromJSON(data []byte) (*Module, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseModule(json)
+}
+
+// NewNilFromJSON constructs a Nil from JSON, decoded with the current
+// Backend (see SetJSONBackend).
+func NewNilFromJSON(data []byte) (*Nil, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseNil(json)
+}
+
+// NewBooleanFromJSON constructs a Boolean from JSON, decoded with the
+// current Backend (see SetJSONBackend).
+func NewBooleanFromJSON(data []byte) (*Boolean, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseBoolean(json)
+}
+
+// NewInt32FromJSON constructs an Int32 from JSON, decoded with the current
+// Backend (see SetJSONBackend).
+func NewInt32FromJSON(data []byte) (*Int32, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseInt32(json)
+}
+
+// NewFloat64FromJSON constructs a Float64 from JSON, decoded with the
+// current Backend (see SetJSONBackend).
+func NewFloat64FromJSON(data []byte) (*Float64, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseFloat64(json)
+}
+
+// NewStringFromJSON constructs a String from JSON, decoded with the current
+// Backend (see SetJSONBackend).
+func NewStringFromJSON(data []byte) (*String, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseString(json)
+}
+
+// NewIDFromJSON constructs an ID from JSON, decoded with the current Backend
+// (see SetJSONBackend).
+func NewIDFromJSON(data []byte) (*ID, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseID(json)
+}
+
+// NewDefinitionFromJSON constructs a Definition from JSON, decoded with the
+// current Backend (see SetJSONBackend).
+func NewDefinitionFromJSON(data []byte) (*Definition, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseDefinition(json)
+}
+
+// NewLambdaFromJSON constructs a Lambda from JSON, decoded with the current
+// Backend (see SetJSONBackend).
+func NewLambdaFromJSON(data []byte) (*Lambda, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseLambda(json)
+}
+
+// NewCallFromJSON constructs a Call from JSON, decoded with the current
+// Backend (see SetJSONBackend).
+func NewCallFromJSON(data []byte) (*Call, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseCall(json)
+}
+
+// NewAnonymousCallFromJSON constructs an AnonymousCall from JSON, decoded
+// with the current Backend (see SetJSONBackend).
+func NewAnonymousCallFromJSON(data []byte) (*AnonymousCall, error) {
+	json, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseAnonymousCall(json)
+}
+
+// parseExpression dispatches on the "type" discriminator to the concrete
+// type's parse function. Unlike the New*FromJSON constructors, it operates
+// on an already-decoded jsonAny so that recursive parsing never pays for a
+// second decode pass.
+func parseExpression(json jsonAny) (Expression, error) {
 	switch json.Get("type").ToUint() {
 	case TypeModule:
-		return NewModuleFromJSON(json)
+		return parseModule(json)
 	case TypeNil:
-		return NewNilFromJSON(json)
+		return parseNil(json)
 	case TypeBoolean:
-		return NewBooleanFromJSON(json)
+		return parseBoolean(json)
 	case TypeInt32:
-		return NewInt32FromJSON(json)
+		return parseInt32(json)
 	case TypeFloat64:
-		return NewFloat64FromJSON(json)
+		return parseFloat64(json)
 	case TypeString:
-		return NewStringFromJSON(json)
+		return parseString(json)
 	case TypeID:
-		return NewIDFromJSON(json)
+		return parseID(json)
 	case TypeDefinition:
-		return NewDefinitionFromJSON(json)
+		return parseDefinition(json)
 	case TypeLambda:
-		return NewLambdaFromJSON(json)
+		return parseLambda(json)
 	case TypeCall:
-		return NewCallFromJSON(json)
+		return parseCall(json)
 	case TypeAnonymousCall:
-		return NewAnonymousCallFromJSON(json)
+		return parseAnonymousCall(json)
 	default:
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(optionalPositionFromJSON(json))
 	}
 }
 
-// NewModuleFromJSON constructs a Module from JSON.
-func NewModuleFromJSON(json jsoniter.Any) (*Module, error) {
+func parseModule(json jsonAny) (*Module, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeModule {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
 	name := json.Get("name").ToString()
 
-	var jsonDefinitions []jsoniter.Any
-	json.Get("definitions").ToVal(&jsonDefinitions)
-
-	definitions := make([]*Definition, len(jsonDefinitions))
-	for i, json := range jsonDefinitions {
-		d, err := NewDefinitionFromJSON(json)
-		if err != nil {
-			return nil, err
+	jsonDefinitions := json.Get("definitions").Array()
+	var definitions []*Definition
+	if len(jsonDefinitions) > 0 {
+		definitions = make([]*Definition, len(jsonDefinitions))
+		for i, json := range jsonDefinitions {
+			d, err := parseDefinition(json)
+			if err != nil {
+				return nil, err
+			}
+			definitions[i] = d
 		}
-		definitions[i] = d
 	}
 
 	return &Module{
 		Name:        name,
 		Definitions: definitions,
+		Position:    position,
 	}, nil
 }
 
-// NewNilFromJSON constructs a Nil from JSON.
-func NewNilFromJSON(json jsoniter.Any) (*Nil, error) {
+func parseNil(json jsonAny) (*Nil, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeNil {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
-	return &Nil{}, nil
+	return &Nil{Position: position}, nil
 }
 
-// NewBooleanFromJSON constructs a Boolean from JSON.
-func NewBooleanFromJSON(json jsoniter.Any) (*Boolean, error) {
+func parseBoolean(json jsonAny) (*Boolean, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeBoolean {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
 	value := json.Get("value").ToBool()
-	return &Boolean{Value: value}, nil
+	return &Boolean{Value: value, Position: position}, nil
 }
 
-// NewInt32FromJSON constructs an Int32 from JSON.
-func NewInt32FromJSON(json jsoniter.Any) (*Int32, error) {
+func parseInt32(json jsonAny) (*Int32, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeInt32 {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
 	value, err := strconv.ParseInt(json.Get("value").ToString(), 10, 32)
 	if err != nil {
 		return nil, err
 	}
-	return &Int32{Value: int32(value)}, nil
+	return &Int32{Value: int32(value), Position: position}, nil
 }
 
-// NewFloat64FromJSON constructs a Float64 from JSON.
-func NewFloat64FromJSON(json jsoniter.Any) (*Float64, error) {
+func parseFloat64(json jsonAny) (*Float64, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeFloat64 {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
 	value, err := strconv.ParseFloat(json.Get("value").ToString(), 64)
 	if err != nil {
 		return nil, err
 	}
-	return &Float64{Value: float64(value)}, nil
+	return &Float64{Value: value, Position: position}, nil
 }
 
-// NewStringFromJSON constructs a String from JSON.
-func NewStringFromJSON(json jsoniter.Any) (*String, error) {
+func parseString(json jsonAny) (*String, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeString {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
 	value := json.Get("value").ToString()
-	return &String{Value: value}, nil
+	return &String{Value: value, Position: position}, nil
 }
 
-// NewIDFromJSON constructs an ID from JSON.
-func NewIDFromJSON(json jsoniter.Any) (*ID, error) {
+func parseID(json jsonAny) (*ID, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeID {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
 	value := json.Get("value").ToString()
-	return &ID{Value: value}, nil
+	return &ID{Value: value, Position: position}, nil
 }
 
-// NewDefinitionFromJSON constructs a Definition from JSON.
-func NewDefinitionFromJSON(json jsoniter.Any) (*Definition, error) {
+func parseDefinition(json jsonAny) (*Definition, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeDefinition {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
-	id, err := NewIDFromJSON(json.Get("id"))
+	id, err := parseID(json.Get("id"))
 	if err != nil {
 		return nil, err
 	}
 
-	expression, err := NewExpressionFromJSON(json.Get("expression"))
+	annotation, err := parseTypeAnnotation(json.Get("typeAnnotation"))
+	if err != nil {
+		return nil, err
+	}
+
+	expression, err := parseExpression(json.Get("expression"))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Definition{
 		ID:         id,
+		Annotation: annotation,
 		Expression: expression,
+		Position:   position,
 	}, nil
 }
 
-// NewLambdaFromJSON constructs a Lambda from JSON.
-func NewLambdaFromJSON(json jsoniter.Any) (*Lambda, error) {
+func parseLambda(json jsonAny) (*Lambda, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeLambda {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
-	var jsonParameters []jsoniter.Any
-	json.Get("parameters").ToVal(&jsonParameters)
-
+	jsonParameters := json.Get("parameters").Array()
 	parameters := make([]*ID, len(jsonParameters))
 	for i, json := range jsonParameters {
-		p, err := NewIDFromJSON(json)
+		p, err := parseID(json)
 		if err != nil {
 			return nil, err
 		}
 		parameters[i] = p
 	}
 
-	expression, err := NewExpressionFromJSON(json.Get("expression"))
+	jsonParameterAnnotations := json.Get("parameterAnnotations").Array()
+	var parameterAnnotations []*TypeAnnotation
+	if len(jsonParameterAnnotations) > 0 {
+		parameterAnnotations = make([]*TypeAnnotation, len(jsonParameterAnnotations))
+		for i, json := range jsonParameterAnnotations {
+			a, err := parseTypeAnnotation(json)
+			if err != nil {
+				return nil, err
+			}
+			parameterAnnotations[i] = a
+		}
+	}
+
+	returnAnnotation, err := parseTypeAnnotation(json.Get("returnAnnotation"))
+	if err != nil {
+		return nil, err
+	}
+
+	expression, err := parseExpression(json.Get("expression"))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Lambda{
-		Parameters: parameters,
-		Expression: expression,
+		Parameters:           parameters,
+		ParameterAnnotations: parameterAnnotations,
+		ReturnAnnotation:     returnAnnotation,
+		Expression:           expression,
+		Position:             position,
 	}, nil
 }
 
-// NewCallFromJSON constructs a Call from JSON.
-func NewCallFromJSON(json jsoniter.Any) (*Call, error) {
+// parseTypeAnnotation parses a TypeAnnotation, returning nil if json is
+// absent or null so that unannotated definitions and parameters round-trip
+// cleanly.
+func parseTypeAnnotation(json jsonAny) (*TypeAnnotation, error) {
+	if !json.Exists() || json.IsNull() {
+		return nil, nil
+	}
+
+	if ret := json.Get("ret"); ret.Exists() {
+		retAnnotation, err := parseTypeAnnotation(ret)
+		if err != nil {
+			return nil, err
+		}
+
+		jsonParams := json.Get("params").Array()
+		params := make([]*TypeAnnotation, len(jsonParams))
+		for i, json := range jsonParams {
+			p, err := parseTypeAnnotation(json)
+			if err != nil {
+				return nil, err
+			}
+			params[i] = p
+		}
+
+		return &TypeAnnotation{Params: params, Ret: retAnnotation}, nil
+	}
+
+	return &TypeAnnotation{Value: json.Get("value").ToString()}, nil
+}
+
+func parseCall(json jsonAny) (*Call, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeCall {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
-	id, err := NewIDFromJSON(json.Get("id"))
+	id, err := parseID(json.Get("id"))
 	if err != nil {
 		return nil, err
 	}
 
-	var jsonArguments []jsoniter.Any
-	json.Get("arguments").ToVal(&jsonArguments)
-
+	jsonArguments := json.Get("arguments").Array()
 	arguments := make([]Expression, len(jsonArguments))
 	for i, json := range jsonArguments {
-		a, err := NewExpressionFromJSON(json)
+		a, err := parseExpression(json)
 		if err != nil {
 			return nil, err
 		}
@@ -208,26 +387,25 @@ func NewCallFromJSON(json jsoniter.Any) (*Call, error) {
 	return &Call{
 		ID:        id,
 		Arguments: arguments,
+		Position:  position,
 	}, nil
 }
 
-// NewAnonymousCallFromJSON constructs an AnonymousCall from JSON.
-func NewAnonymousCallFromJSON(json jsoniter.Any) (*AnonymousCall, error) {
+func parseAnonymousCall(json jsonAny) (*AnonymousCall, error) {
+	position := optionalPositionFromJSON(json)
 	if json.Get("type").ToUint() != TypeAnonymousCall {
-		return nil, ErrInvalidJSON
+		return nil, invalidJSONError(position)
 	}
 
-	lambda, err := NewLambdaFromJSON(json.Get("lambda"))
+	lambda, err := parseLambda(json.Get("lambda"))
 	if err != nil {
 		return nil, err
 	}
 
-	var jsonArguments []jsoniter.Any
-	json.Get("arguments").ToVal(&jsonArguments)
-
+	jsonArguments := json.Get("arguments").Array()
 	arguments := make([]Expression, len(jsonArguments))
 	for i, json := range jsonArguments {
-		a, err := NewExpressionFromJSON(json)
+		a, err := parseExpression(json)
 		if err != nil {
 			return nil, err
 		}
@@ -237,6 +415,7 @@ func NewAnonymousCallFromJSON(json jsoniter.Any) (*AnonymousCall, error) {
 	return &AnonymousCall{
 		Lambda:    lambda,
 		Arguments: arguments,
+		Position:  position,
 	}, nil
 }
 
@@ -247,64 +426,69 @@ func (m *Module) JSON() map[string]interface{} {
 		definitions[i] = d.JSON()
 	}
 
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":        TypeModule,
+		"name":        m.Name,
 		"definitions": definitions,
-	}
+	}, m.Position)
 }
 
 // JSON returns a JSON representation of the Nil.
 func (n *Nil) JSON() map[string]interface{} {
-	return map[string]interface{}{"type": TypeNil}
+	return withPosition(map[string]interface{}{"type": TypeNil}, n.Position)
 }
 
 // JSON returns a JSON representation of the Boolean.
 func (b *Boolean) JSON() map[string]interface{} {
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":  TypeBoolean,
 		"value": b.Value,
-	}
+	}, b.Position)
 }
 
 // JSON returns a JSON representation of the Int32.
 func (i *Int32) JSON() map[string]interface{} {
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":  TypeInt32,
 		"value": strconv.FormatInt(int64(i.Value), 10),
-	}
+	}, i.Position)
 }
 
 // JSON returns a JSON representation of the Float64.
 func (f *Float64) JSON() map[string]interface{} {
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":  TypeFloat64,
 		"value": strconv.FormatFloat(f.Value, 'f', -1, 64),
-	}
+	}, f.Position)
 }
 
 // JSON returns a JSON representation of the String.
 func (s *String) JSON() map[string]interface{} {
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":  TypeString,
 		"value": s.Value,
-	}
+	}, s.Position)
 }
 
 // JSON returns a JSON representation of the ID.
 func (i *ID) JSON() map[string]interface{} {
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":  TypeID,
 		"value": i.Value,
-	}
+	}, i.Position)
 }
 
 // JSON returns a JSON representation of the Definition.
 func (d *Definition) JSON() map[string]interface{} {
-	return map[string]interface{}{
+	json := map[string]interface{}{
 		"type":       TypeDefinition,
 		"id":         d.ID.JSON(),
 		"expression": d.Expression.JSON(),
 	}
+	if d.Annotation != nil {
+		json["typeAnnotation"] = d.Annotation.JSON()
+	}
+	return withPosition(json, d.Position)
 }
 
 // JSON returns a JSON representation of the Lambda.
@@ -314,11 +498,39 @@ func (l *Lambda) JSON() map[string]interface{} {
 		parameters[i] = p.JSON()
 	}
 
-	return map[string]interface{}{
+	json := map[string]interface{}{
 		"type":       TypeLambda,
 		"parameters": parameters,
 		"expression": l.Expression.JSON(),
 	}
+	if len(l.ParameterAnnotations) > 0 {
+		parameterAnnotations := make([]interface{}, len(l.ParameterAnnotations))
+		for i, a := range l.ParameterAnnotations {
+			if a != nil {
+				parameterAnnotations[i] = a.JSON()
+			}
+		}
+		json["parameterAnnotations"] = parameterAnnotations
+	}
+	if l.ReturnAnnotation != nil {
+		json["returnAnnotation"] = l.ReturnAnnotation.JSON()
+	}
+	return withPosition(json, l.Position)
+}
+
+// JSON returns a JSON representation of the TypeAnnotation.
+func (t *TypeAnnotation) JSON() map[string]interface{} {
+	if t.IsFunction() {
+		params := make([]map[string]interface{}, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = p.JSON()
+		}
+		return map[string]interface{}{
+			"params": params,
+			"ret":    t.Ret.JSON(),
+		}
+	}
+	return map[string]interface{}{"value": t.Value}
 }
 
 // JSON returns a JSON representation of the Call.
@@ -328,11 +540,11 @@ func (c *Call) JSON() map[string]interface{} {
 		arguments[i] = a.JSON()
 	}
 
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":      TypeCall,
 		"id":        c.ID.JSON(),
 		"arguments": arguments,
-	}
+	}, c.Position)
 }
 
 // JSON returns a JSON representation of the AnonymousCall.
@@ -342,15 +554,15 @@ func (c *AnonymousCall) JSON() map[string]interface{} {
 		arguments[i] = a.JSON()
 	}
 
-	return map[string]interface{}{
+	return withPosition(map[string]interface{}{
 		"type":      TypeAnonymousCall,
 		"lambda":    c.Lambda.JSON(),
 		"arguments": arguments,
-	}
+	}, c.Position)
 }
 
 func (m *Module) String() string {
-	b, err := jsoniter.Marshal(m.JSON())
+	b, err := json.Marshal(m.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -358,7 +570,7 @@ func (m *Module) String() string {
 }
 
 func (n *Nil) String() string {
-	b, err := jsoniter.Marshal(n.JSON())
+	b, err := json.Marshal(n.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -366,7 +578,7 @@ func (n *Nil) String() string {
 }
 
 func (b *Boolean) String() string {
-	bytes, err := jsoniter.Marshal(b.JSON())
+	bytes, err := json.Marshal(b.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -374,7 +586,7 @@ func (b *Boolean) String() string {
 }
 
 func (i *Int32) String() string {
-	b, err := jsoniter.Marshal(i.JSON())
+	b, err := json.Marshal(i.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -382,7 +594,7 @@ func (i *Int32) String() string {
 }
 
 func (f *Float64) String() string {
-	b, err := jsoniter.Marshal(f.JSON())
+	b, err := json.Marshal(f.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -390,7 +602,7 @@ func (f *Float64) String() string {
 }
 
 func (s *String) String() string {
-	b, err := jsoniter.Marshal(s.JSON())
+	b, err := json.Marshal(s.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -398,7 +610,7 @@ func (s *String) String() string {
 }
 
 func (i *ID) String() string {
-	b, err := jsoniter.Marshal(i.JSON())
+	b, err := json.Marshal(i.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -406,7 +618,7 @@ func (i *ID) String() string {
 }
 
 func (d *Definition) String() string {
-	b, err := jsoniter.Marshal(d.JSON())
+	b, err := json.Marshal(d.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -414,7 +626,7 @@ func (d *Definition) String() string {
 }
 
 func (l *Lambda) String() string {
-	b, err := jsoniter.Marshal(l.JSON())
+	b, err := json.Marshal(l.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -422,7 +634,7 @@ func (l *Lambda) String() string {
 }
 
 func (c *Call) String() string {
-	b, err := jsoniter.Marshal(c.JSON())
+	b, err := json.Marshal(c.JSON())
 	if err != nil {
 		return err.Error()
 	}
@@ -430,9 +642,26 @@ func (c *Call) String() string {
 }
 
 func (c *AnonymousCall) String() string {
-	b, err := jsoniter.Marshal(c.JSON())
+	b, err := json.Marshal(c.JSON())
 	if err != nil {
 		return err.Error()
 	}
 	return string(b)
 }
+
+// String returns the TypeAnnotation's algebraic notation, e.g. "int32" or
+// "(int32, string) -> bool".
+func (t *TypeAnnotation) String() string {
+	if t == nil {
+		return TypeAny
+	}
+	if !t.IsFunction() {
+		return t.Value
+	}
+
+	params := make([]string, len(t.Params))
+	for i, p := range t.Params {
+		params[i] = p.String()
+	}
+	return "(" + strings.Join(params, ", ") + ") -> " + t.Ret.String()
+}
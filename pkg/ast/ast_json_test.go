@@ -1,11 +1,10 @@
 package ast
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
-
-	jsoniter "github.com/json-iterator/go"
 )
 
 func TestNewExpressionFromJSON(t *testing.T) {
@@ -24,10 +23,23 @@ func TestNewExpressionFromJSON(t *testing.T) {
 			ID:         &ID{Value: "definitionID"},
 			Expression: &Int32{Value: -42},
 		},
+		&Definition{
+			ID:         &ID{Value: "typedDefinitionID"},
+			Annotation: &TypeAnnotation{Value: TypeAnnotationInt32},
+			Expression: &Int32{Value: -42},
+		},
 		&Lambda{
 			Parameters: []*ID{{Value: "x"}},
 			Expression: &ID{Value: "x"},
 		},
+		&Lambda{
+			Parameters: []*ID{{Value: "x"}},
+			ParameterAnnotations: []*TypeAnnotation{
+				{Value: TypeAnnotationInt32},
+			},
+			ReturnAnnotation: &TypeAnnotation{Value: TypeAnnotationInt32},
+			Expression:       &ID{Value: "x"},
+		},
 		&Call{
 			ID:        &ID{Value: "test"},
 			Arguments: []Expression{&Float64{Value: 123.456}},
@@ -43,13 +55,13 @@ func TestNewExpressionFromJSON(t *testing.T) {
 
 	for _, e := range expressions {
 		t.Run(fmt.Sprintf("%T", e), func(t *testing.T) {
-			b, err := jsoniter.Marshal(e.JSON())
+			b, err := json.Marshal(e.JSON())
 			if err != nil {
 				t.Error(err)
 			}
 			t.Log(string(b))
 
-			parsedExpression, err := NewExpressionFromJSON(jsoniter.Get(b))
+			parsedExpression, err := NewExpressionFromJSON(b)
 			if err != nil {
 				t.Error(err)
 			}
@@ -67,15 +79,15 @@ func TestNewExpressionFromJSON(t *testing.T) {
 	}
 	t.Run("invalid type", func(t *testing.T) {
 		e := &Boolean{Value: true}
-		json := e.JSON()
-		json["type"] = 0
-		b, err := jsoniter.Marshal(json)
+		obj := e.JSON()
+		obj["type"] = 0
+		b, err := json.Marshal(obj)
 		if err != nil {
 			t.Error(err)
 		}
 		t.Log(string(b))
 
-		_, err = NewExpressionFromJSON(jsoniter.Get(b))
+		_, err = NewExpressionFromJSON(b)
 		if err != ErrInvalidJSON {
 			t.Errorf("expected %v, got %v", ErrInvalidJSON, err)
 		}
@@ -0,0 +1,141 @@
+package ast
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+func formatJSONNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// jsonReader decodes raw JSON bytes into the generic representation
+// NewExpressionFromJSON and friends traverse. Adapters for other JSON
+// engines (json-iterator, goccy/go-json, ...) implement this, letting the
+// choice of engine stay an implementation detail rather than part of the
+// AST package's public surface.
+type jsonReader interface {
+	unmarshalAny(data []byte) (interface{}, error)
+}
+
+// A Backend is a pluggable JSON engine used to decode the raw bytes passed
+// to NewExpressionFromJSON and friends. See SetJSONBackend.
+type Backend = jsonReader
+
+type stdJSONBackend struct{}
+
+func (stdJSONBackend) unmarshalAny(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// StdJSONBackend decodes using the standard library's encoding/json. It is
+// the default backend.
+var StdJSONBackend Backend = stdJSONBackend{}
+
+var currentBackend = StdJSONBackend
+
+// SetJSONBackend sets the JSON engine used by NewExpressionFromJSON and
+// friends to decode raw bytes. It defaults to StdJSONBackend so that
+// importing this package does not transitively pull in a third-party JSON
+// library; build with the jsoniter or goccy build tag and call this with
+// JSONIterBackend or GoccyJSONBackend to opt into a faster engine.
+func SetJSONBackend(b Backend) {
+	currentBackend = b
+}
+
+// jsonAny is a backend-agnostic view over a decoded JSON value. It mirrors
+// the subset of jsoniter.Any's navigation API that the parse* functions
+// need, backed by the plain interface{} tree any Backend produces.
+type jsonAny struct {
+	v      interface{}
+	exists bool
+}
+
+func decodeJSON(data []byte) (jsonAny, error) {
+	v, err := currentBackend.unmarshalAny(data)
+	if err != nil {
+		return jsonAny{}, err
+	}
+	return jsonAny{v: v, exists: true}, nil
+}
+
+// Get returns the value at key, or a value for which Exists reports false if
+// the receiver is not an object or key is absent.
+func (a jsonAny) Get(key string) jsonAny {
+	if m, ok := a.v.(map[string]interface{}); ok {
+		if val, ok := m[key]; ok {
+			return jsonAny{v: val, exists: true}
+		}
+	}
+	return jsonAny{}
+}
+
+// Exists reports whether the value was present in its parent object/array.
+func (a jsonAny) Exists() bool {
+	return a.exists
+}
+
+// IsObject reports whether the value is a JSON object.
+func (a jsonAny) IsObject() bool {
+	_, ok := a.v.(map[string]interface{})
+	return ok
+}
+
+// IsNull reports whether the value is present and JSON null.
+func (a jsonAny) IsNull() bool {
+	return a.exists && a.v == nil
+}
+
+// Array returns the elements of a JSON array, or nil if the value is not an
+// array.
+func (a jsonAny) Array() []jsonAny {
+	arr, ok := a.v.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	elements := make([]jsonAny, len(arr))
+	for i, v := range arr {
+		elements[i] = jsonAny{v: v, exists: true}
+	}
+	return elements
+}
+
+// ToString returns the value as a string, converting numbers and returning
+// the empty string for anything else.
+func (a jsonAny) ToString() string {
+	switch v := a.v.(type) {
+	case string:
+		return v
+	case float64:
+		return formatJSONNumber(v)
+	default:
+		return ""
+	}
+}
+
+// ToUint returns the value as a uint, or 0 if it is not a number.
+func (a jsonAny) ToUint() uint {
+	if f, ok := a.v.(float64); ok {
+		return uint(f)
+	}
+	return 0
+}
+
+// ToInt returns the value as an int, or 0 if it is not a number.
+func (a jsonAny) ToInt() int {
+	if f, ok := a.v.(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+// ToBool returns the value as a bool, or false if it is not a boolean.
+func (a jsonAny) ToBool() bool {
+	b, _ := a.v.(bool)
+	return b
+}
@@ -0,0 +1,22 @@
+//go:build goccy
+
+package ast
+
+import (
+	goccyjson "github.com/goccy/go-json"
+)
+
+type goccyBackend struct{}
+
+func (goccyBackend) unmarshalAny(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := goccyjson.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GoccyJSONBackend decodes using github.com/goccy/go-json. Only available
+// when built with the goccy build tag, so that importing this package does
+// not transitively pull in goccy/go-json by default.
+var GoccyJSONBackend Backend = goccyBackend{}
@@ -0,0 +1,22 @@
+//go:build jsoniter
+
+package ast
+
+import (
+	jsoniter "github.com/json-iterator/go"
+)
+
+type jsoniterBackend struct{}
+
+func (jsoniterBackend) unmarshalAny(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := jsoniter.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// JSONIterBackend decodes using github.com/json-iterator/go. Only available
+// when built with the jsoniter build tag, so that importing this package
+// does not transitively pull in jsoniter by default.
+var JSONIterBackend Backend = jsoniterBackend{}
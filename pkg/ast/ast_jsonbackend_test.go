@@ -0,0 +1,28 @@
+package ast
+
+import "testing"
+
+func TestSetJSONBackendDefaultsToStdlib(t *testing.T) {
+	if currentBackend != StdJSONBackend {
+		t.Errorf("expected default backend to be StdJSONBackend")
+	}
+}
+
+func TestSetJSONBackendSwitchesBackend(t *testing.T) {
+	defer SetJSONBackend(StdJSONBackend)
+
+	custom := stdJSONBackend{}
+	SetJSONBackend(custom)
+	if currentBackend != Backend(custom) {
+		t.Errorf("expected currentBackend to be set to custom backend")
+	}
+
+	e, err := NewExpressionFromJSON([]byte(`{"type":3,"value":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := e.(*Boolean)
+	if !ok || !b.Value {
+		t.Errorf("expected *Boolean{Value: true}, got %#v", e)
+	}
+}
@@ -0,0 +1,92 @@
+package ast
+
+import (
+	"fmt"
+)
+
+// A Position describes the source location of an expression, for use in
+// diagnostics.
+type Position struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	Offset    int    `json:"offset"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	EndOffset int    `json:"endOffset"`
+}
+
+// JSON returns a JSON representation of the Position.
+func (p *Position) JSON() map[string]interface{} {
+	return map[string]interface{}{
+		"file":      p.File,
+		"line":      p.Line,
+		"column":    p.Column,
+		"offset":    p.Offset,
+		"endLine":   p.EndLine,
+		"endColumn": p.EndColumn,
+		"endOffset": p.EndOffset,
+	}
+}
+
+// withPosition sets the "pos" key on json when p is non-nil, and returns
+// json unchanged otherwise.
+func withPosition(json map[string]interface{}, p *Position) map[string]interface{} {
+	if p != nil {
+		json["pos"] = p.JSON()
+	}
+	return json
+}
+
+// optionalPositionFromJSON reads the "pos" key from json, returning nil if
+// it is absent so that payloads without position metadata remain valid.
+func optionalPositionFromJSON(json jsonAny) *Position {
+	posJSON := json.Get("pos")
+	if !posJSON.IsObject() {
+		return nil
+	}
+
+	return &Position{
+		File:      posJSON.Get("file").ToString(),
+		Line:      posJSON.Get("line").ToInt(),
+		Column:    posJSON.Get("column").ToInt(),
+		Offset:    posJSON.Get("offset").ToInt(),
+		EndLine:   posJSON.Get("endLine").ToInt(),
+		EndColumn: posJSON.Get("endColumn").ToInt(),
+		EndOffset: posJSON.Get("endOffset").ToInt(),
+	}
+}
+
+// invalidJSONError returns ErrInvalidJSON, wrapped in a PositionalError when
+// pos is known so the caller gets a file:line:col-prefixed message. It is
+// always raised for a type discriminator that doesn't match the parser being
+// called, so the offending payload never yields a constructed Expression to
+// attach to the error; PositionalError deliberately carries no Node field
+// for that reason.
+func invalidJSONError(pos *Position) error {
+	if pos == nil {
+		return ErrInvalidJSON
+	}
+	return &PositionalError{Position: pos, Err: ErrInvalidJSON}
+}
+
+// A PositionalError wraps an error with the Position it occurred at, so
+// downstream tools can render file:line:col diagnostics.
+type PositionalError struct {
+	Position *Position
+	Err      error
+}
+
+// Error returns a file:line:col-prefixed message when Position is known,
+// falling back to the wrapped error's message otherwise.
+func (e *PositionalError) Error() string {
+	if e.Position == nil || e.Position.File == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Position.File, e.Position.Line, e.Position.Column, e.Err)
+}
+
+// Unwrap returns the wrapped error.
+func (e *PositionalError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,88 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPositionRoundTrip(t *testing.T) {
+	id := &ID{
+		Value: "x",
+		Position: &Position{
+			File:   "main.rogue",
+			Line:   3,
+			Column: 5,
+			Offset: 42,
+		},
+	}
+
+	b, err := json.Marshal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ID
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if *decoded.Position != *id.Position {
+		t.Errorf("expected %+v, got %+v", id.Position, decoded.Position)
+	}
+
+	parsed, err := NewIDFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed.Position != *id.Position {
+		t.Errorf("expected %+v, got %+v", id.Position, parsed.Position)
+	}
+}
+
+func TestPositionAbsentIsBackwardCompatible(t *testing.T) {
+	b := []byte(`{"type":7,"value":"x"}`)
+
+	parsed, err := NewIDFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Position != nil {
+		t.Errorf("expected nil Position, got %+v", parsed.Position)
+	}
+
+	var decoded ID
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Position != nil {
+		t.Errorf("expected nil Position, got %+v", decoded.Position)
+	}
+}
+
+func TestPositionalError(t *testing.T) {
+	e := &PositionalError{
+		Position: &Position{File: "main.rogue", Line: 3, Column: 5},
+		Err:      ErrInvalidJSON,
+	}
+
+	expected := "main.rogue:3:5: invalid JSON"
+	if e.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, e.Error())
+	}
+
+	if e.Unwrap() != ErrInvalidJSON {
+		t.Errorf("expected %v, got %v", ErrInvalidJSON, e.Unwrap())
+	}
+}
+
+func TestNewIDFromJSONInvalidTypeWithPosition(t *testing.T) {
+	b := []byte(`{"type":0,"value":"x","pos":{"file":"main.rogue","line":1,"column":1}}`)
+
+	_, err := NewIDFromJSON(b)
+	positionalErr, ok := err.(*PositionalError)
+	if !ok {
+		t.Fatalf("expected *PositionalError, got %T", err)
+	}
+	if positionalErr.Error() != "main.rogue:1:1: invalid JSON" {
+		t.Errorf("unexpected message: %s", positionalErr.Error())
+	}
+}
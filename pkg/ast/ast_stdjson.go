@@ -0,0 +1,561 @@
+package ast
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// decodePosition decodes the "pos" key's raw JSON, returning nil when it is
+// absent so payloads without position metadata remain valid.
+func decodePosition(raw json.RawMessage) (*Position, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var position Position
+	if err := json.Unmarshal(raw, &position); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// decodeTypeAnnotation decodes a TypeAnnotation's raw JSON, returning nil
+// when it is absent or null so that unannotated definitions and parameters
+// round-trip cleanly.
+func decodeTypeAnnotation(raw json.RawMessage) (*TypeAnnotation, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var annotation TypeAnnotation
+	if err := annotation.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return &annotation, nil
+}
+
+// MarshalJSON returns a JSON representation of the TypeAnnotation.
+func (t *TypeAnnotation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.JSON())
+}
+
+// UnmarshalJSON populates the TypeAnnotation from JSON.
+func (t *TypeAnnotation) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Value  string            `json:"value"`
+		Params []json.RawMessage `json:"params"`
+		Ret    json.RawMessage   `json:"ret"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Ret) == 0 {
+		t.Value = aux.Value
+		t.Params = nil
+		t.Ret = nil
+		return nil
+	}
+
+	ret, err := decodeTypeAnnotation(aux.Ret)
+	if err != nil {
+		return err
+	}
+
+	params := make([]*TypeAnnotation, len(aux.Params))
+	for i, raw := range aux.Params {
+		p, err := decodeTypeAnnotation(raw)
+		if err != nil {
+			return err
+		}
+		params[i] = p
+	}
+
+	t.Value = ""
+	t.Params = params
+	t.Ret = ret
+	return nil
+}
+
+// ExpressionEnvelope wraps an Expression so it can be unmarshaled with the
+// standard library: it peeks at the "type" discriminator, dispatches to the
+// concrete type's UnmarshalJSON, and stores the result in Expression.
+type ExpressionEnvelope struct {
+	Expression Expression
+}
+
+// MarshalJSON returns a JSON representation of the wrapped Expression.
+func (e ExpressionEnvelope) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Expression)
+}
+
+// UnmarshalJSON populates Expression from JSON by dispatching on the "type"
+// discriminator.
+func (e *ExpressionEnvelope) UnmarshalJSON(data []byte) error {
+	var discriminator struct {
+		Type Type            `json:"type"`
+		Pos  json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &discriminator); err != nil {
+		return err
+	}
+
+	var expression Expression
+	switch discriminator.Type {
+	case TypeModule:
+		expression = &Module{}
+	case TypeNil:
+		expression = &Nil{}
+	case TypeBoolean:
+		expression = &Boolean{}
+	case TypeInt32:
+		expression = &Int32{}
+	case TypeFloat64:
+		expression = &Float64{}
+	case TypeString:
+		expression = &String{}
+	case TypeID:
+		expression = &ID{}
+	case TypeDefinition:
+		expression = &Definition{}
+	case TypeLambda:
+		expression = &Lambda{}
+	case TypeCall:
+		expression = &Call{}
+	case TypeAnonymousCall:
+		expression = &AnonymousCall{}
+	default:
+		position, err := decodePosition(discriminator.Pos)
+		if err != nil {
+			return err
+		}
+		return invalidJSONError(position)
+	}
+
+	if err := json.Unmarshal(data, expression); err != nil {
+		return err
+	}
+	e.Expression = expression
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Module.
+func (m *Module) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.JSON())
+}
+
+// UnmarshalJSON populates the Module from JSON.
+func (m *Module) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type        Type              `json:"type"`
+		Name        string            `json:"name"`
+		Definitions []json.RawMessage `json:"definitions"`
+		Pos         json.RawMessage   `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeModule {
+		return invalidJSONError(position)
+	}
+
+	var definitions []*Definition
+	if len(aux.Definitions) > 0 {
+		definitions = make([]*Definition, len(aux.Definitions))
+		for i, raw := range aux.Definitions {
+			d := &Definition{}
+			if err := d.UnmarshalJSON(raw); err != nil {
+				return err
+			}
+			definitions[i] = d
+		}
+	}
+
+	m.Name = aux.Name
+	m.Definitions = definitions
+	m.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Nil.
+func (n *Nil) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.JSON())
+}
+
+// UnmarshalJSON populates the Nil from JSON.
+func (n *Nil) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type Type            `json:"type"`
+		Pos  json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeNil {
+		return invalidJSONError(position)
+	}
+	n.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Boolean.
+func (b *Boolean) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.JSON())
+}
+
+// UnmarshalJSON populates the Boolean from JSON.
+func (b *Boolean) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type  Type            `json:"type"`
+		Value bool            `json:"value"`
+		Pos   json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeBoolean {
+		return invalidJSONError(position)
+	}
+	b.Value = aux.Value
+	b.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Int32.
+func (i *Int32) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.JSON())
+}
+
+// UnmarshalJSON populates the Int32 from JSON.
+func (i *Int32) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type  Type            `json:"type"`
+		Value string          `json:"value"`
+		Pos   json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeInt32 {
+		return invalidJSONError(position)
+	}
+
+	value, err := strconv.ParseInt(aux.Value, 10, 32)
+	if err != nil {
+		return err
+	}
+	i.Value = int32(value)
+	i.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Float64.
+func (f *Float64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.JSON())
+}
+
+// UnmarshalJSON populates the Float64 from JSON.
+func (f *Float64) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type  Type            `json:"type"`
+		Value string          `json:"value"`
+		Pos   json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeFloat64 {
+		return invalidJSONError(position)
+	}
+
+	value, err := strconv.ParseFloat(aux.Value, 64)
+	if err != nil {
+		return err
+	}
+	f.Value = value
+	f.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the String.
+func (s *String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.JSON())
+}
+
+// UnmarshalJSON populates the String from JSON.
+func (s *String) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type  Type            `json:"type"`
+		Value string          `json:"value"`
+		Pos   json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeString {
+		return invalidJSONError(position)
+	}
+	s.Value = aux.Value
+	s.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the ID.
+func (i *ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.JSON())
+}
+
+// UnmarshalJSON populates the ID from JSON.
+func (i *ID) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type  Type            `json:"type"`
+		Value string          `json:"value"`
+		Pos   json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeID {
+		return invalidJSONError(position)
+	}
+	i.Value = aux.Value
+	i.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Definition.
+func (d *Definition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.JSON())
+}
+
+// UnmarshalJSON populates the Definition from JSON.
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type           Type            `json:"type"`
+		ID             json.RawMessage `json:"id"`
+		TypeAnnotation json.RawMessage `json:"typeAnnotation,omitempty"`
+		Expression     json.RawMessage `json:"expression"`
+		Pos            json.RawMessage `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeDefinition {
+		return invalidJSONError(position)
+	}
+
+	id := &ID{}
+	if err := id.UnmarshalJSON(aux.ID); err != nil {
+		return err
+	}
+
+	annotation, err := decodeTypeAnnotation(aux.TypeAnnotation)
+	if err != nil {
+		return err
+	}
+
+	var envelope ExpressionEnvelope
+	if err := envelope.UnmarshalJSON(aux.Expression); err != nil {
+		return err
+	}
+
+	d.ID = id
+	d.Annotation = annotation
+	d.Expression = envelope.Expression
+	d.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Lambda.
+func (l *Lambda) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.JSON())
+}
+
+// UnmarshalJSON populates the Lambda from JSON.
+func (l *Lambda) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type                 Type              `json:"type"`
+		Parameters           []json.RawMessage `json:"parameters"`
+		ParameterAnnotations []json.RawMessage `json:"parameterAnnotations,omitempty"`
+		ReturnAnnotation     json.RawMessage   `json:"returnAnnotation,omitempty"`
+		Expression           json.RawMessage   `json:"expression"`
+		Pos                  json.RawMessage   `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeLambda {
+		return invalidJSONError(position)
+	}
+
+	parameters := make([]*ID, len(aux.Parameters))
+	for i, raw := range aux.Parameters {
+		p := &ID{}
+		if err := p.UnmarshalJSON(raw); err != nil {
+			return err
+		}
+		parameters[i] = p
+	}
+
+	var parameterAnnotations []*TypeAnnotation
+	if len(aux.ParameterAnnotations) > 0 {
+		parameterAnnotations = make([]*TypeAnnotation, len(aux.ParameterAnnotations))
+		for i, raw := range aux.ParameterAnnotations {
+			a, err := decodeTypeAnnotation(raw)
+			if err != nil {
+				return err
+			}
+			parameterAnnotations[i] = a
+		}
+	}
+
+	returnAnnotation, err := decodeTypeAnnotation(aux.ReturnAnnotation)
+	if err != nil {
+		return err
+	}
+
+	var envelope ExpressionEnvelope
+	if err := envelope.UnmarshalJSON(aux.Expression); err != nil {
+		return err
+	}
+
+	l.Parameters = parameters
+	l.ParameterAnnotations = parameterAnnotations
+	l.ReturnAnnotation = returnAnnotation
+	l.Expression = envelope.Expression
+	l.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the Call.
+func (c *Call) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.JSON())
+}
+
+// UnmarshalJSON populates the Call from JSON.
+func (c *Call) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type      Type              `json:"type"`
+		ID        json.RawMessage   `json:"id"`
+		Arguments []json.RawMessage `json:"arguments"`
+		Pos       json.RawMessage   `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeCall {
+		return invalidJSONError(position)
+	}
+
+	id := &ID{}
+	if err := id.UnmarshalJSON(aux.ID); err != nil {
+		return err
+	}
+
+	arguments := make([]Expression, len(aux.Arguments))
+	for i, raw := range aux.Arguments {
+		var envelope ExpressionEnvelope
+		if err := envelope.UnmarshalJSON(raw); err != nil {
+			return err
+		}
+		arguments[i] = envelope.Expression
+	}
+
+	c.ID = id
+	c.Arguments = arguments
+	c.Position = position
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the AnonymousCall.
+func (c *AnonymousCall) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.JSON())
+}
+
+// UnmarshalJSON populates the AnonymousCall from JSON.
+func (c *AnonymousCall) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type      Type              `json:"type"`
+		Lambda    json.RawMessage   `json:"lambda"`
+		Arguments []json.RawMessage `json:"arguments"`
+		Pos       json.RawMessage   `json:"pos,omitempty"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	position, err := decodePosition(aux.Pos)
+	if err != nil {
+		return err
+	}
+	if aux.Type != TypeAnonymousCall {
+		return invalidJSONError(position)
+	}
+
+	lambda := &Lambda{}
+	if err := lambda.UnmarshalJSON(aux.Lambda); err != nil {
+		return err
+	}
+
+	arguments := make([]Expression, len(aux.Arguments))
+	for i, raw := range aux.Arguments {
+		var envelope ExpressionEnvelope
+		if err := envelope.UnmarshalJSON(raw); err != nil {
+			return err
+		}
+		arguments[i] = envelope.Expression
+	}
+
+	c.Lambda = lambda
+	c.Arguments = arguments
+	c.Position = position
+	return nil
+}
@@ -0,0 +1,133 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExpressionEnvelopeUnmarshalJSON(t *testing.T) {
+	expressions := []Expression{
+		&Module{
+			Name:        "test",
+			Definitions: nil,
+		},
+		&Nil{},
+		&Boolean{Value: false},
+		&Int32{Value: 23},
+		&Float64{Value: 0.0000582},
+		&String{Value: "Hello, world!"},
+		&ID{Value: "id"},
+		&Definition{
+			ID:         &ID{Value: "definitionID"},
+			Expression: &Int32{Value: -42},
+		},
+		&Definition{
+			ID:         &ID{Value: "typedDefinitionID"},
+			Annotation: &TypeAnnotation{Value: TypeAnnotationInt32},
+			Expression: &Int32{Value: -42},
+		},
+		&Lambda{
+			Parameters: []*ID{{Value: "x"}},
+			Expression: &ID{Value: "x"},
+		},
+		&Lambda{
+			Parameters: []*ID{{Value: "x"}},
+			ParameterAnnotations: []*TypeAnnotation{
+				{Value: TypeAnnotationInt32},
+			},
+			ReturnAnnotation: &TypeAnnotation{Value: TypeAnnotationInt32},
+			Expression:       &ID{Value: "x"},
+		},
+		&Call{
+			ID:        &ID{Value: "test"},
+			Arguments: []Expression{&Float64{Value: 123.456}},
+		},
+		&AnonymousCall{
+			Lambda: &Lambda{
+				Parameters: []*ID{{Value: "a"}},
+				Expression: &ID{Value: "a"},
+			},
+			Arguments: []Expression{&String{Value: "a's value"}},
+		},
+	}
+
+	for _, e := range expressions {
+		t.Run(fmt.Sprintf("%T", e), func(t *testing.T) {
+			b, err := json.Marshal(e)
+			if err != nil {
+				t.Error(err)
+			}
+			t.Log(string(b))
+
+			var envelope ExpressionEnvelope
+			if err := json.Unmarshal(b, &envelope); err != nil {
+				t.Error(err)
+			}
+
+			expectedTypeOf, actualTypeOf := reflect.TypeOf(e), reflect.TypeOf(envelope.Expression)
+			if actualTypeOf != expectedTypeOf {
+				t.Errorf("expected %v, got %v", expectedTypeOf, actualTypeOf)
+			}
+
+			expectedType, actualType := e.Type(), envelope.Expression.Type()
+			if actualType != expectedType {
+				t.Errorf("expected %v, got %v", expectedType, actualType)
+			}
+
+			if !reflect.DeepEqual(e, envelope.Expression) {
+				t.Errorf("expected %#v, got %#v", e, envelope.Expression)
+			}
+		})
+	}
+
+	t.Run("invalid type", func(t *testing.T) {
+		e := &Boolean{Value: true}
+		obj := e.JSON()
+		obj["type"] = 0
+		b, err := json.Marshal(obj)
+		if err != nil {
+			t.Error(err)
+		}
+		t.Log(string(b))
+
+		var envelope ExpressionEnvelope
+		err = envelope.UnmarshalJSON(b)
+		if err != ErrInvalidJSON {
+			t.Errorf("expected %v, got %v", ErrInvalidJSON, err)
+		}
+	})
+}
+
+func TestASTEncodingJSONRoundTrip(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID: &ID{Value: "main"},
+				Expression: &Lambda{
+					Parameters: []*ID{{Value: "x"}},
+					Expression: &Call{
+						ID:        &ID{Value: "double"},
+						Arguments: []Expression{&ID{Value: "x"}},
+					},
+				},
+			},
+		},
+	}
+
+	b, err := json.Marshal(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Module
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(module, &decoded) {
+		t.Errorf("expected %#v, got %#v", module, &decoded)
+	}
+}
@@ -0,0 +1,224 @@
+package ast
+
+import (
+	"fmt"
+)
+
+// anyTypeAnnotation is returned for expressions and parameters whose type
+// could not be determined, such as an unannotated parameter or an ID that
+// is not in scope.
+var anyTypeAnnotation = &TypeAnnotation{Value: TypeAny}
+
+// A TypeError describes a type-checking failure found by Check.
+type TypeError struct {
+	Position *Position
+	Message  string
+}
+
+// Error returns a file:line:col-prefixed message when Position is known,
+// falling back to the bare message otherwise.
+func (e *TypeError) Error() string {
+	if e.Position == nil || e.Position.File == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Position.File, e.Position.Line, e.Position.Column, e.Message)
+}
+
+// Check type-checks m, validating Call and AnonymousCall arity and argument
+// types against the callee's signature. It builds an environment mapping
+// each top-level Definition's ID to its declared or inferred type, then
+// checks every definition's expression against that environment.
+// A definition without an annotation takes the type inferred from its
+// expression (TypeAny when none can be inferred); parameters and return
+// types without an annotation are treated as TypeAny, so typed and untyped
+// code can mix freely.
+func Check(m *Module) []TypeError {
+	env := make(map[string]*TypeAnnotation, len(m.Definitions))
+	for _, d := range m.Definitions {
+		if d.Annotation != nil {
+			env[d.ID.Value] = d.Annotation
+		} else {
+			env[d.ID.Value] = inferredType(d.Expression, env)
+		}
+	}
+
+	c := &checker{}
+	for _, d := range m.Definitions {
+		c.check(d.Expression, env)
+	}
+	return c.errs
+}
+
+// inferredType returns e's type without type-checking it, used to seed env
+// for definitions that have no declared annotation. A Lambda's type is built
+// from its own parameter and return annotations; a Call's type is TypeAny.
+func inferredType(e Expression, env map[string]*TypeAnnotation) *TypeAnnotation {
+	switch e := e.(type) {
+	case *Nil:
+		return &TypeAnnotation{Value: TypeAnnotationNil}
+	case *Boolean:
+		return &TypeAnnotation{Value: TypeAnnotationBoolean}
+	case *Int32:
+		return &TypeAnnotation{Value: TypeAnnotationInt32}
+	case *Float64:
+		return &TypeAnnotation{Value: TypeAnnotationFloat64}
+	case *String:
+		return &TypeAnnotation{Value: TypeAnnotationString}
+	case *ID:
+		if t, ok := env[e.Value]; ok {
+			return t
+		}
+		return anyTypeAnnotation
+	case *Lambda:
+		return lambdaSignature(e)
+	default:
+		return anyTypeAnnotation
+	}
+}
+
+// lambdaSignature returns l's function TypeAnnotation, substituting
+// TypeAny for any parameter or return type that isn't annotated.
+func lambdaSignature(l *Lambda) *TypeAnnotation {
+	params := make([]*TypeAnnotation, len(l.Parameters))
+	for i := range l.Parameters {
+		if i < len(l.ParameterAnnotations) && l.ParameterAnnotations[i] != nil {
+			params[i] = l.ParameterAnnotations[i]
+		} else {
+			params[i] = anyTypeAnnotation
+		}
+	}
+
+	ret := l.ReturnAnnotation
+	if ret == nil {
+		ret = anyTypeAnnotation
+	}
+
+	return &TypeAnnotation{Params: params, Ret: ret}
+}
+
+// assignable reports whether a value of type from may be used where a value
+// of type to is expected. TypeAny is compatible with everything, on either
+// side, so unannotated code never trips the checker.
+func assignable(from, to *TypeAnnotation) bool {
+	if from.Value == TypeAny || to.Value == TypeAny {
+		return true
+	}
+	if from.IsFunction() != to.IsFunction() {
+		return false
+	}
+	if !from.IsFunction() {
+		return from.Value == to.Value
+	}
+	if len(from.Params) != len(to.Params) {
+		return false
+	}
+	for i := range from.Params {
+		if !assignable(from.Params[i], to.Params[i]) {
+			return false
+		}
+	}
+	return assignable(from.Ret, to.Ret)
+}
+
+// A checker accumulates TypeErrors while checking an Expression tree,
+// tracking a per-scope environment of declared or inferred types.
+type checker struct {
+	errs []TypeError
+}
+
+func (c *checker) check(e Expression, env map[string]*TypeAnnotation) *TypeAnnotation {
+	switch e := e.(type) {
+	case *Nil:
+		return &TypeAnnotation{Value: TypeAnnotationNil}
+	case *Boolean:
+		return &TypeAnnotation{Value: TypeAnnotationBoolean}
+	case *Int32:
+		return &TypeAnnotation{Value: TypeAnnotationInt32}
+	case *Float64:
+		return &TypeAnnotation{Value: TypeAnnotationFloat64}
+	case *String:
+		return &TypeAnnotation{Value: TypeAnnotationString}
+	case *ID:
+		if t, ok := env[e.Value]; ok {
+			return t
+		}
+		return anyTypeAnnotation
+	case *Lambda:
+		return c.checkLambda(e, env)
+	case *Call:
+		return c.checkCall(e, env)
+	case *AnonymousCall:
+		return c.checkAnonymousCall(e, env)
+	default:
+		return anyTypeAnnotation
+	}
+}
+
+func (c *checker) checkLambda(l *Lambda, env map[string]*TypeAnnotation) *TypeAnnotation {
+	signature := lambdaSignature(l)
+
+	inner := make(map[string]*TypeAnnotation, len(env)+len(l.Parameters))
+	for k, v := range env {
+		inner[k] = v
+	}
+	for i, p := range l.Parameters {
+		inner[p.Value] = signature.Params[i]
+	}
+
+	bodyType := c.check(l.Expression, inner)
+	if !assignable(bodyType, signature.Ret) {
+		c.errorf(l.Position, "lambda body has type %s, expected return type %s", bodyType, signature.Ret)
+	}
+	return signature
+}
+
+func (c *checker) checkCall(call *Call, env map[string]*TypeAnnotation) *TypeAnnotation {
+	callee, ok := env[call.ID.Value]
+	if !ok {
+		callee = anyTypeAnnotation
+	}
+	if callee.Value == TypeAny {
+		for _, a := range call.Arguments {
+			c.check(a, env)
+		}
+		return anyTypeAnnotation
+	}
+	if !callee.IsFunction() {
+		c.errorf(call.Position, "cannot call %s: has type %s, not a procedure", call.ID.Value, callee)
+		for _, a := range call.Arguments {
+			c.check(a, env)
+		}
+		return anyTypeAnnotation
+	}
+	return c.checkArguments(call.Position, call.ID.Value, callee, call.Arguments, env)
+}
+
+func (c *checker) checkAnonymousCall(call *AnonymousCall, env map[string]*TypeAnnotation) *TypeAnnotation {
+	callee := c.checkLambda(call.Lambda, env)
+	return c.checkArguments(call.Position, "lambda", callee, call.Arguments, env)
+}
+
+// checkArguments validates arguments against callee's parameters, checking
+// every argument expression regardless of an arity mismatch so nested
+// errors are still reported.
+func (c *checker) checkArguments(pos *Position, name string, callee *TypeAnnotation, arguments []Expression, env map[string]*TypeAnnotation) *TypeAnnotation {
+	if len(arguments) != len(callee.Params) {
+		c.errorf(pos, "%s takes %d argument(s), got %d", name, len(callee.Params), len(arguments))
+		for _, a := range arguments {
+			c.check(a, env)
+		}
+		return anyTypeAnnotation
+	}
+
+	for i, a := range arguments {
+		argType := c.check(a, env)
+		if !assignable(argType, callee.Params[i]) {
+			c.errorf(pos, "argument %d to %s has type %s, expected %s", i, name, argType, callee.Params[i])
+		}
+	}
+	return callee.Ret
+}
+
+func (c *checker) errorf(pos *Position, format string, args ...interface{}) {
+	c.errs = append(c.errs, TypeError{Position: pos, Message: fmt.Sprintf(format, args...)})
+}
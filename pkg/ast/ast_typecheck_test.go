@@ -0,0 +1,151 @@
+package ast
+
+import "testing"
+
+func int32Annotation() *TypeAnnotation {
+	return &TypeAnnotation{Value: TypeAnnotationInt32}
+}
+
+func TestCheckValidModule(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID:         &ID{Value: "double"},
+				Annotation: &TypeAnnotation{Params: []*TypeAnnotation{int32Annotation()}, Ret: int32Annotation()},
+				Expression: &Lambda{
+					Parameters:           []*ID{{Value: "x"}},
+					ParameterAnnotations: []*TypeAnnotation{int32Annotation()},
+					ReturnAnnotation:     int32Annotation(),
+					Expression:           &ID{Value: "x"},
+				},
+			},
+			{
+				ID: &ID{Value: "main"},
+				Expression: &Call{
+					ID:        &ID{Value: "double"},
+					Arguments: []Expression{&Int32{Value: 1}},
+				},
+			},
+		},
+	}
+
+	if errs := Check(module); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestCheckDetectsArgumentTypeMismatch(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID: &ID{Value: "double"},
+				Expression: &Lambda{
+					Parameters:           []*ID{{Value: "x"}},
+					ParameterAnnotations: []*TypeAnnotation{int32Annotation()},
+					ReturnAnnotation:     int32Annotation(),
+					Expression:           &ID{Value: "x"},
+				},
+			},
+			{
+				ID: &ID{Value: "main"},
+				Expression: &Call{
+					ID:        &ID{Value: "double"},
+					Arguments: []Expression{&String{Value: "not an int32"}},
+				},
+			},
+		},
+	}
+
+	errs := Check(module)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+}
+
+func TestCheckDetectsArityMismatch(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID: &ID{Value: "double"},
+				Expression: &Lambda{
+					Parameters:           []*ID{{Value: "x"}},
+					ParameterAnnotations: []*TypeAnnotation{int32Annotation()},
+					ReturnAnnotation:     int32Annotation(),
+					Expression:           &ID{Value: "x"},
+				},
+			},
+			{
+				ID: &ID{Value: "main"},
+				Expression: &Call{
+					ID:        &ID{Value: "double"},
+					Arguments: []Expression{&Int32{Value: 1}, &Int32{Value: 2}},
+				},
+			},
+		},
+	}
+
+	errs := Check(module)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+}
+
+func TestCheckAllowsUnannotatedCode(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID: &ID{Value: "identity"},
+				Expression: &Lambda{
+					Parameters: []*ID{{Value: "x"}},
+					Expression: &ID{Value: "x"},
+				},
+			},
+			{
+				ID: &ID{Value: "main"},
+				Expression: &Call{
+					ID:        &ID{Value: "identity"},
+					Arguments: []Expression{&String{Value: "anything goes"}},
+				},
+			},
+		},
+	}
+
+	if errs := Check(module); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestCheckDetectsAnonymousCallMismatch(t *testing.T) {
+	module := &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID: &ID{Value: "main"},
+				Expression: &AnonymousCall{
+					Lambda: &Lambda{
+						Parameters:           []*ID{{Value: "x"}},
+						ParameterAnnotations: []*TypeAnnotation{int32Annotation()},
+						Expression:           &ID{Value: "x"},
+					},
+					Arguments: []Expression{&Boolean{Value: true}},
+				},
+			},
+		},
+	}
+
+	errs := Check(module)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+}
+
+func TestTypeAnnotationString(t *testing.T) {
+	fn := &TypeAnnotation{Params: []*TypeAnnotation{int32Annotation(), {Value: TypeAnnotationString}}, Ret: &TypeAnnotation{Value: TypeAnnotationBoolean}}
+	if got, want := fn.String(), "(int32, string) -> bool"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
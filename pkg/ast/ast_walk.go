@@ -0,0 +1,125 @@
+package ast
+
+// A Visitor visits nodes of an Expression tree. If Visit returns a non-nil
+// Visitor w, Walk visits each child of e with w, then calls w.Visit(nil).
+type Visitor interface {
+	Visit(e Expression) (w Visitor)
+}
+
+// Walk traverses an Expression tree in depth-first order: it calls v.Visit(e)
+// and, if the returned Visitor is non-nil, recurses into e's children before
+// calling that Visitor's Visit(nil).
+func Walk(e Expression, v Visitor) {
+	if v = v.Visit(e); v == nil {
+		return
+	}
+
+	switch e := e.(type) {
+	case *Module:
+		for _, d := range e.Definitions {
+			Walk(d, v)
+		}
+	case *Nil, *Boolean, *Int32, *Float64, *String, *ID:
+		// leaf nodes have no children
+	case *Definition:
+		Walk(e.Expression, v)
+	case *Lambda:
+		for _, p := range e.Parameters {
+			Walk(p, v)
+		}
+		Walk(e.Expression, v)
+	case *Call:
+		for _, a := range e.Arguments {
+			Walk(a, v)
+		}
+	case *AnonymousCall:
+		Walk(e.Lambda, v)
+		for _, a := range e.Arguments {
+			Walk(a, v)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Expression) bool
+
+func (f inspector) Visit(e Expression) Visitor {
+	if f(e) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an Expression tree in depth-first order, calling f for
+// each node. If f returns false for a node, that node's children are
+// skipped; traversal of the rest of the tree continues. It does not call f
+// with a nil Expression.
+func Inspect(e Expression, f func(Expression) bool) {
+	Walk(e, inspector(func(e Expression) bool {
+		if e == nil {
+			return true
+		}
+		return f(e)
+	}))
+}
+
+// Transform returns a new Expression tree with every node replaced by
+// f(node), applied bottom-up so f sees already-rewritten children.
+func Transform(e Expression, f func(Expression) Expression) Expression {
+	switch e := e.(type) {
+	case *Module:
+		definitions := make([]*Definition, len(e.Definitions))
+		for i, d := range e.Definitions {
+			definitions[i] = Transform(d, f).(*Definition)
+		}
+		return f(&Module{
+			Name:        e.Name,
+			Definitions: definitions,
+			Position:    e.Position,
+		})
+	case *Nil, *Boolean, *Int32, *Float64, *String, *ID:
+		return f(e)
+	case *Definition:
+		return f(&Definition{
+			ID:         e.ID,
+			Annotation: e.Annotation,
+			Expression: Transform(e.Expression, f),
+			Position:   e.Position,
+		})
+	case *Lambda:
+		parameters := make([]*ID, len(e.Parameters))
+		for i, p := range e.Parameters {
+			parameters[i] = Transform(p, f).(*ID)
+		}
+		return f(&Lambda{
+			Parameters:           parameters,
+			ParameterAnnotations: e.ParameterAnnotations,
+			ReturnAnnotation:     e.ReturnAnnotation,
+			Expression:           Transform(e.Expression, f),
+			Position:             e.Position,
+		})
+	case *Call:
+		arguments := make([]Expression, len(e.Arguments))
+		for i, a := range e.Arguments {
+			arguments[i] = Transform(a, f)
+		}
+		return f(&Call{
+			ID:        e.ID,
+			Arguments: arguments,
+			Position:  e.Position,
+		})
+	case *AnonymousCall:
+		arguments := make([]Expression, len(e.Arguments))
+		for i, a := range e.Arguments {
+			arguments[i] = Transform(a, f)
+		}
+		return f(&AnonymousCall{
+			Lambda:    Transform(e.Lambda, f).(*Lambda),
+			Arguments: arguments,
+			Position:  e.Position,
+		})
+	default:
+		return f(e)
+	}
+}
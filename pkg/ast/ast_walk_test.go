@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testModule() *Module {
+	return &Module{
+		Name: "test",
+		Definitions: []*Definition{
+			{
+				ID: &ID{Value: "main"},
+				Expression: &Lambda{
+					Parameters: []*ID{{Value: "x"}},
+					Expression: &Call{
+						ID:        &ID{Value: "double"},
+						Arguments: []Expression{&ID{Value: "x"}, &Int32{Value: 1}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var types []Type
+	Inspect(testModule(), func(e Expression) bool {
+		types = append(types, e.Type())
+		return true
+	})
+
+	expected := []Type{
+		TypeModule,
+		TypeDefinition,
+		TypeLambda,
+		TypeID,
+		TypeCall,
+		TypeID,
+		TypeInt32,
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+}
+
+func TestInspectStopsEarly(t *testing.T) {
+	count := 0
+	Inspect(testModule(), func(e Expression) bool {
+		count++
+		return e.Type() != TypeDefinition
+	})
+
+	if count != 2 {
+		t.Errorf("expected 2 visits, got %d", count)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	m := testModule()
+
+	transformed := Transform(m, func(e Expression) Expression {
+		if id, ok := e.(*ID); ok && id.Value == "x" {
+			return &ID{Value: "renamed"}
+		}
+		return e
+	}).(*Module)
+
+	lambda := transformed.Definitions[0].Expression.(*Lambda)
+	if lambda.Parameters[0].Value != "renamed" {
+		t.Errorf("expected renamed parameter, got %v", lambda.Parameters[0].Value)
+	}
+
+	call := lambda.Expression.(*Call)
+	if call.Arguments[0].(*ID).Value != "renamed" {
+		t.Errorf("expected renamed argument, got %v", call.Arguments[0])
+	}
+
+	// the original tree is untouched
+	original := m.Definitions[0].Expression.(*Lambda)
+	if original.Parameters[0].Value != "x" {
+		t.Errorf("expected original tree to be unmodified, got %v", original.Parameters[0].Value)
+	}
+}